@@ -0,0 +1,144 @@
+// Package cache persists a compiled Bytecode to disk, keyed by a stable
+// hash of its instructions and constants, so recompiling the same
+// program can skip straight to a cached result instead of re-running the
+// compiler.
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+
+	"github.com/ape-lang/ape/src/compiler/compiler"
+	"github.com/ape-lang/ape/src/compiler/sourcemap"
+	"github.com/ape-lang/ape/src/data"
+)
+
+const envOverride = "APE_CACHE_DIR"
+
+func init() {
+	// Every concrete data.Data type that can end up in a Bytecode's
+	// Constants has to be registered so gob can round-trip the
+	// data.Data interface values inside it.
+	gob.Register(&data.Integer{})
+	gob.Register(&data.String{})
+	gob.Register(&data.Boolean{})
+	gob.Register(&data.Null{})
+	gob.Register(&data.Array{})
+	gob.Register(&data.Hash{})
+	gob.Register(&data.Error{})
+	gob.Register(&data.CompiledFunction{})
+}
+
+// Cache persists compiled Bytecode to disk under a directory, one file
+// per cache key.
+type Cache struct {
+	dir string
+}
+
+// New creates a Cache rooted at dir, creating it if necessary. An empty
+// dir resolves, in order, to $APE_CACHE_DIR, $XDG_CACHE_HOME/ape, or
+// $HOME/.cache/ape.
+func New(dir string) (*Cache, error) {
+	if dir == "" {
+		dir = defaultDir()
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cache: %w", err)
+	}
+	return &Cache{dir: dir}, nil
+}
+
+func defaultDir() string {
+	if dir := os.Getenv(envOverride); dir != "" {
+		return dir
+	}
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "ape")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".cache", "ape")
+	}
+	return filepath.Join(os.TempDir(), "ape-cache")
+}
+
+// Key returns a stable hash of bc's instructions and every constant it
+// references, including the body of any nested CompiledFunction, so two
+// programs that would execute differently never collide.
+func Key(bc *compiler.Bytecode) string {
+	h := sha256.New()
+	h.Write(bc.Instructions)
+	for _, constant := range bc.Constants {
+		hashConstant(h, constant)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func hashConstant(h hash.Hash, d data.Data) {
+	fmt.Fprintf(h, "%s:", d.Type())
+
+	if fn, ok := d.(*data.CompiledFunction); ok {
+		h.Write(fn.Instructions)
+		fmt.Fprintf(h, ":%d:%d", fn.LocalCount, fn.ParamCount)
+		return
+	}
+
+	fmt.Fprint(h, d.Inspect())
+}
+
+// Load returns the Bytecode stored under key, and whether it was found.
+func (c *Cache) Load(key string) (*compiler.Bytecode, bool) {
+	raw, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var bc compiler.Bytecode
+	if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&bc); err != nil {
+		return nil, false
+	}
+	return &bc, true
+}
+
+// Store persists bc under key, overwriting any previous entry. gob cannot
+// encode a nil slice element, so every nil entry of bc.ConstantSourceMaps
+// is stored as an empty map; Load returns an empty map, not nil, there.
+func (c *Cache) Store(key string, bc *compiler.Bytecode) error {
+	stored := *bc
+	stored.ConstantSourceMaps = make([]*sourcemap.Map, len(bc.ConstantSourceMaps))
+	for i, m := range bc.ConstantSourceMaps {
+		if m == nil {
+			m = sourcemap.New()
+		}
+		stored.ConstantSourceMaps[i] = m
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&stored); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	return os.WriteFile(c.path(key), buf.Bytes(), 0o644)
+}
+
+// Clear removes every entry from the cache.
+func (c *Cache) Clear() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.Remove(filepath.Join(c.dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/ape-lang/ape/src/compiler/compiler"
+	"github.com/ape-lang/ape/src/compiler/operation"
+	"github.com/ape-lang/ape/src/compiler/sourcemap"
+	"github.com/ape-lang/ape/src/data"
+)
+
+// Bytecode's source maps round-trip through gob just like everything
+// else in it. Before sourcemap.Map.Entries was exported, gob silently
+// dropped it on both encode and decode, so a program loaded from a warm
+// cache lost its source map without ever returning an error.
+func TestStoreLoad_PreservesSourceMaps(t *testing.T) {
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	rootMap := sourcemap.New()
+	rootMap.Record(0, "let x = 5;")
+
+	fnMap := sourcemap.New()
+	fnMap.Record(0, "x + 1;")
+
+	fn := &data.CompiledFunction{Instructions: operation.NewInstruction(operation.ReturnValue)}
+
+	bc := &compiler.Bytecode{
+		Instructions:       operation.NewInstruction(operation.Pop),
+		Constants:          []data.Data{fn},
+		SourceMap:          rootMap,
+		ConstantSourceMaps: []*sourcemap.Map{fnMap},
+	}
+
+	key := Key(bc)
+	if err := c.Store(key, bc); err != nil {
+		t.Fatalf("Store returned error: %v", err)
+	}
+
+	loaded, ok := c.Load(key)
+	if !ok {
+		t.Fatal("expected Load to find the stored entry")
+	}
+
+	if loaded.SourceMap == nil || len(loaded.SourceMap.Entries) != 1 {
+		t.Fatalf("root source map did not round-trip: %#v", loaded.SourceMap)
+	}
+	if got, want := loaded.SourceMap.Entries[0].Source, "let x = 5;"; got != want {
+		t.Errorf("root source map entry = %q, want %q", got, want)
+	}
+
+	if len(loaded.ConstantSourceMaps) != 1 || loaded.ConstantSourceMaps[0] == nil {
+		t.Fatal("function source map did not round-trip")
+	}
+	if got, want := loaded.ConstantSourceMaps[0].Entries[0].Source, "x + 1;"; got != want {
+		t.Errorf("function source map entry = %q, want %q", got, want)
+	}
+}
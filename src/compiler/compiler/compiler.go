@@ -5,7 +5,9 @@ import (
 	"sort"
 
 	"github.com/ape-lang/ape/src/ast"
+	"github.com/ape-lang/ape/src/compiler/module"
 	"github.com/ape-lang/ape/src/compiler/operation"
+	"github.com/ape-lang/ape/src/compiler/sourcemap"
 	"github.com/ape-lang/ape/src/compiler/symbols"
 	"github.com/ape-lang/ape/src/data"
 )
@@ -14,6 +16,13 @@ import (
 type Bytecode struct {
 	Instructions operation.Instruction
 	Constants    []data.Data
+	SourceMap    *sourcemap.Map
+
+	// ConstantSourceMaps holds the source map for every constant in
+	// Constants that is a *data.CompiledFunction (nil elsewhere), since
+	// a function's instructions are their own scope with their own
+	// offsets starting at 0, not an extension of SourceMap's.
+	ConstantSourceMaps []*sourcemap.Map
 }
 
 // Emitted represents an emitted instruction
@@ -24,10 +33,15 @@ type Emitted struct {
 
 // Compiler contains the instructions and constants which will then be turned into bytecode
 type Compiler struct {
-	constants    []data.Data
-	symbols      *symbols.SymbolTable
-	scopes       []Scope
-	currentScope int
+	constants          []data.Data
+	constantSourceMaps []*sourcemap.Map // parallel to constants; set for constants that are *data.CompiledFunction, nil elsewhere
+	symbols            *symbols.SymbolTable
+	scopes             []Scope
+	currentScope       int
+
+	modules          *module.Registry
+	compiledModules  map[string]int  // module name -> constant pool index of its exports function
+	moduleReferenced map[string]bool // modules imported anywhere in this compilation
 }
 
 // Scope contains the scope of the compilation
@@ -35,6 +49,21 @@ type Scope struct {
 	instructions operation.Instruction // The instructions that will be compiled
 	emitted      Emitted               // The last emitted instruction
 	prevEmitted  Emitted               // The emitted instruction before that
+
+	// sourceMap links this scope's own instructions back to the ape
+	// source that produced them. Every scope's instructions start over
+	// at offset 0 (the main program's and every function/module body's
+	// alike), so each scope keeps its own map rather than sharing one.
+	sourceMap *sourcemap.Map
+
+	// loops tracks the break/continue jump positions of whatever loops
+	// are currently open in this scope, innermost last. It lives on the
+	// scope rather than the Compiler so that a function literal compiled
+	// while an enclosing loop is still open starts with an empty stack of
+	// its own: a `break` inside the function body resolves against a
+	// loop in that function, never one outside it whose instructions are
+	// a different, already-finished byte offset space.
+	loops []*loopCtx
 }
 
 // New creates a new compiler
@@ -43,6 +72,7 @@ func New() *Compiler {
 		instructions: operation.Instruction{},
 		emitted:      Emitted{},
 		prevEmitted:  Emitted{},
+		sourceMap:    sourcemap.New(),
 	}
 
 	symbolTable := symbols.New()
@@ -51,10 +81,15 @@ func New() *Compiler {
 	}
 
 	return &Compiler{
-		constants:    []data.Data{},
-		symbols:      symbolTable,
-		scopes:       []Scope{rootScope},
-		currentScope: 0,
+		constants:          []data.Data{},
+		constantSourceMaps: []*sourcemap.Map{},
+		symbols:            symbolTable,
+		scopes:             []Scope{rootScope},
+		currentScope:       0,
+
+		modules:          module.NewRegistry(),
+		compiledModules:  map[string]int{},
+		moduleReferenced: map[string]bool{},
 	}
 }
 
@@ -71,10 +106,12 @@ func (c *Compiler) Compile(node ast.Node) error {
 	switch node := node.(type) {
 	case *ast.Program:
 		for _, s := range node.Statements {
+			startPos := len(c.currentInstructions())
 			err := c.Compile(s)
 			if err != nil {
 				return err
 			}
+			c.currentSourceMap().Record(startPos, s.String())
 		}
 
 	case *ast.ExpressionStatement:
@@ -203,6 +240,64 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 		c.emit(operation.Index)
 
+	case *ast.CheckedIndexExpression:
+		err := c.Compile(node.Left)
+		if err != nil {
+			return err
+		}
+		err = c.Compile(node.Index)
+		if err != nil {
+			return err
+		}
+		c.emit(operation.IndexChecked)
+
+	case *ast.WhileExpression:
+		err := c.compileWhile(node)
+		if err != nil {
+			return err
+		}
+
+	case *ast.DoWhileExpression:
+		err := c.compileDoWhile(node)
+		if err != nil {
+			return err
+		}
+
+	case *ast.ForExpression:
+		err := c.compileFor(node)
+		if err != nil {
+			return err
+		}
+
+	case *ast.BreakStatement:
+		if len(c.currentLoops()) == 0 {
+			return fmt.Errorf("break outside of a loop")
+		}
+		pos := c.emit(operation.LoopExit, 9999)
+		loop := c.currentLoop()
+		loop.breaks = append(loop.breaks, pos)
+
+	case *ast.ContinueStatement:
+		if len(c.currentLoops()) == 0 {
+			return fmt.Errorf("continue outside of a loop")
+		}
+		pos := c.emit(operation.LoopExit, 9999)
+		loop := c.currentLoop()
+		loop.continues = append(loop.continues, pos)
+
+	case *ast.TryStatement:
+		err := c.compileTry(node)
+		if err != nil {
+			return err
+		}
+
+	case *ast.RaiseStatement:
+		err := c.Compile(node.Value)
+		if err != nil {
+			return err
+		}
+		c.emit(operation.Raise)
+
 	case *ast.IfExpression:
 		err := c.Compile(node.Condition)
 		if err != nil {
@@ -260,10 +355,12 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 	case *ast.BlockStatement:
 		for _, s := range node.Statements {
+			startPos := len(c.currentInstructions())
 			err := c.Compile(s)
 			if err != nil {
 				return err
 			}
+			c.currentSourceMap().Record(startPos, s.String())
 		}
 
 	case *ast.FunctionLiteral:
@@ -288,6 +385,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 
 		freeSymbols := c.symbols.Free
 		localCount := c.symbols.DefinitionCount
+		fnSourceMap := c.currentSourceMap()
 		instructions := c.leaveScope()
 
 		for _, s := range freeSymbols {
@@ -301,6 +399,7 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 
 		fnIndex := c.addConstant(compiled)
+		c.constantSourceMaps[fnIndex] = fnSourceMap
 		c.emit(operation.Closure, fnIndex, len(freeSymbols))
 
 	case *ast.ReturnStatement:
@@ -323,6 +422,14 @@ func (c *Compiler) Compile(node ast.Node) error {
 		}
 		c.emit(operation.Call, len(node.Arguments))
 
+	case *ast.ImportExpression:
+		fnIndex, err := c.compileModule(node.ModuleName)
+		if err != nil {
+			return err
+		}
+		c.emit(operation.Constant, fnIndex)
+		c.emit(operation.Call, 0)
+
 	}
 
 	return nil
@@ -331,14 +438,31 @@ func (c *Compiler) Compile(node ast.Node) error {
 // Bytecode produces bytecode out of the compiler result
 func (c *Compiler) Bytecode() *Bytecode {
 	return &Bytecode{
-		Instructions: c.currentInstructions(),
-		Constants:    c.constants,
+		Instructions:       c.currentInstructions(),
+		Constants:          c.constants,
+		SourceMap:          c.currentSourceMap(),
+		ConstantSourceMaps: c.constantSourceMaps,
 	}
 }
 
+// DumpSourceMap renders the root scope's instruction-offset ->
+// source-snippet map built up while compiling, for editor tooling that
+// wants to resolve a generated-bytecode offset on its own rather than
+// through a runtime error. It does not include the source maps of any
+// function or module body compiled along the way; those live alongside
+// their *data.CompiledFunction in Bytecode.ConstantSourceMaps.
+func (c *Compiler) DumpSourceMap() string {
+	return c.currentSourceMap().Dump()
+}
+
 // Adds a constant to the constant pool and returns its index so it can be referenced
 func (c *Compiler) addConstant(d data.Data) int {
 	c.constants = append(c.constants, d)
+	// Pad rather than append once: NewWithState can seed constants that
+	// have no source map entries yet.
+	for len(c.constantSourceMaps) < len(c.constants) {
+		c.constantSourceMaps = append(c.constantSourceMaps, nil)
+	}
 	return len(c.constants) - 1
 }
 
@@ -412,12 +536,20 @@ func (c *Compiler) currentInstructions() operation.Instruction {
 	return c.scopes[c.currentScope].instructions
 }
 
+// Returns the source map for the current compiler scope, recording
+// offsets relative to that scope's own instructions rather than the
+// whole program's.
+func (c *Compiler) currentSourceMap() *sourcemap.Map {
+	return c.scopes[c.currentScope].sourceMap
+}
+
 // Enters a new compilation scope
 func (c *Compiler) enterScope() {
 	scope := Scope{
 		instructions: operation.Instruction{},
 		emitted:      Emitted{},
 		prevEmitted:  Emitted{},
+		sourceMap:    sourcemap.New(),
 	}
 	c.scopes = append(c.scopes, scope)
 	c.currentScope++
@@ -0,0 +1,93 @@
+package compiler
+
+import (
+	"github.com/ape-lang/ape/src/ast"
+	"github.com/ape-lang/ape/src/compiler/operation"
+	"github.com/ape-lang/ape/src/compiler/symbols"
+)
+
+// compileTry lowers a `try ... with Exn v -> ... finally ...` block to a
+// Try instruction guarding the protected body, followed by the catch
+// body (if any) and then the finally body (if any), laid out so the
+// success path jumps straight past the catch body to the finally body
+// (or past both, if there isn't one), while a raise unwinds the VM's
+// handler stack back to whichever of those two blocks applies. No
+// special handling is needed here for a `return` or `break`/`continue`
+// inside node.Try or node.Catch: ReturnValue/Return and LoopExit consult
+// the same handler stack at runtime and run finallyPos themselves before
+// actually leaving.
+func (c *Compiler) compileTry(node *ast.TryStatement) error {
+	setupPos := c.emit(operation.Try, operation.NoHandler, operation.NoHandler)
+
+	if err := c.Compile(node.Try); err != nil {
+		return err
+	}
+	if c.isEmitted(operation.Pop) {
+		c.preventPop()
+	}
+	c.emit(operation.PopTry)
+
+	skipHandlersPos := c.emit(operation.Jump, operation.NoHandler)
+
+	catchPos := operation.NoHandler
+	if node.Catch != nil {
+		catchPos = len(c.currentInstructions())
+
+		if node.CatchVar != nil {
+			symbol := c.symbols.Define(node.CatchVar.Value)
+			if symbol.Scope == symbols.GlobalScope {
+				c.emit(operation.SetGlobal, symbol.Index)
+			} else {
+				c.emit(operation.SetLocal, symbol.Index)
+			}
+		} else {
+			c.emit(operation.Pop)
+		}
+
+		if err := c.Compile(node.Catch); err != nil {
+			return err
+		}
+		if c.isEmitted(operation.Pop) {
+			c.preventPop()
+		}
+
+		// raise() re-installs a finally-only handler before jumping into
+		// catch, so that a raise from within the catch body still runs
+		// finally. On the normal, non-raising path out of catch, discard
+		// that handler here instead.
+		if node.Finally != nil {
+			c.emit(operation.PopTry)
+		}
+	}
+
+	finallyPos := operation.NoHandler
+	if node.Finally != nil {
+		finallyPos = len(c.currentInstructions())
+
+		if err := c.Compile(node.Finally); err != nil {
+			return err
+		}
+		if c.isEmitted(operation.Pop) {
+			c.preventPop()
+		}
+		c.emit(operation.EndFinally)
+	}
+
+	afterPos := len(c.currentInstructions())
+
+	skipTarget := afterPos
+	if finallyPos != operation.NoHandler {
+		skipTarget = finallyPos
+	}
+	c.changeOperand(skipHandlersPos, skipTarget)
+	c.changeTryOperands(setupPos, catchPos, finallyPos)
+
+	return nil
+}
+
+// changeTryOperands rewrites both operands of the Try instruction at pos,
+// once the real positions of its catch and finally blocks are known.
+func (c *Compiler) changeTryOperands(pos, catchPos, finallyPos int) {
+	ins := operation.NewInstruction(operation.Try, catchPos, finallyPos)
+	c.changeInstruction(pos, ins)
+}
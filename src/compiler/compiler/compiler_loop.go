@@ -0,0 +1,162 @@
+package compiler
+
+import (
+	"github.com/ape-lang/ape/src/ast"
+	"github.com/ape-lang/ape/src/compiler/operation"
+)
+
+// loopCtx tracks the jump positions emitted by `break` and `continue`
+// inside one loop, so they can be back-patched once the loop's header
+// and end are known: continues to the loop header (or, for `for`, to its
+// post statement), breaks to the instruction right after the loop.
+// Mirrors how an `if`'s own Jump/JumpNotTruthy operands are patched once
+// its branches have been compiled.
+type loopCtx struct {
+	continues []int
+	breaks    []int
+}
+
+func (c *Compiler) enterLoop() {
+	scope := &c.scopes[c.currentScope]
+	scope.loops = append(scope.loops, &loopCtx{})
+}
+
+// currentLoops returns the loop stack of the current scope, so callers
+// outside this file (break/continue compilation) never reach into
+// Scope directly.
+func (c *Compiler) currentLoops() []*loopCtx {
+	return c.scopes[c.currentScope].loops
+}
+
+func (c *Compiler) currentLoop() *loopCtx {
+	loops := c.currentLoops()
+	return loops[len(loops)-1]
+}
+
+// leaveLoop patches every pending `continue` to continueTarget and every
+// pending `break` to breakTarget, then pops the loop off the current
+// scope's stack.
+func (c *Compiler) leaveLoop(continueTarget, breakTarget int) {
+	loop := c.currentLoop()
+
+	for _, pos := range loop.continues {
+		c.changeOperand(pos, continueTarget)
+	}
+	for _, pos := range loop.breaks {
+		c.changeOperand(pos, breakTarget)
+	}
+
+	scope := &c.scopes[c.currentScope]
+	scope.loops = scope.loops[:len(scope.loops)-1]
+}
+
+// compileWhile lowers `while cond { body }`. A `return` inside body needs
+// no special handling here: it emits the usual ReturnValue/Return, which
+// unwinds the whole call frame regardless of any loop the VM happens to
+// be inside.
+func (c *Compiler) compileWhile(node *ast.WhileExpression) error {
+	c.enterLoop()
+
+	conditionPos := len(c.currentInstructions())
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+
+	jumpNotTruthyPos := c.emit(operation.JumpNotTruthy, 9999)
+
+	// Unlike an if-branch, the body runs once per iteration against this
+	// one compiled copy: its trailing expression-statement Pop must stay
+	// in the instruction stream and execute every time, or the operand
+	// stack grows by one slot per iteration. The loop itself still
+	// evaluates to the unconditional Null emitted below, not to the
+	// body's last value.
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+
+	c.emit(operation.Jump, conditionPos)
+
+	afterLoopPos := len(c.currentInstructions())
+	c.changeOperand(jumpNotTruthyPos, afterLoopPos)
+
+	c.leaveLoop(conditionPos, afterLoopPos)
+	c.emit(operation.Null)
+
+	return nil
+}
+
+// compileDoWhile lowers `do { body } while cond`, where the body always
+// runs at least once before the condition is tested.
+func (c *Compiler) compileDoWhile(node *ast.DoWhileExpression) error {
+	c.enterLoop()
+
+	bodyPos := len(c.currentInstructions())
+
+	// See compileWhile: the body's Pop must run every iteration.
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+
+	conditionPos := len(c.currentInstructions())
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+
+	jumpNotTruthyPos := c.emit(operation.JumpNotTruthy, 9999)
+	c.emit(operation.Jump, bodyPos)
+
+	afterLoopPos := len(c.currentInstructions())
+	c.changeOperand(jumpNotTruthyPos, afterLoopPos)
+
+	c.leaveLoop(conditionPos, afterLoopPos)
+	c.emit(operation.Null)
+
+	return nil
+}
+
+// compileFor lowers a C-style `for (init; cond; post) { body }`. A
+// `continue` jumps to the post statement rather than straight back to the
+// condition, so the increment still runs.
+func (c *Compiler) compileFor(node *ast.ForExpression) error {
+	if node.Init != nil {
+		if err := c.Compile(node.Init); err != nil {
+			return err
+		}
+	}
+
+	c.enterLoop()
+
+	conditionPos := len(c.currentInstructions())
+	jumpNotTruthyPos := -1
+	if node.Condition != nil {
+		if err := c.Compile(node.Condition); err != nil {
+			return err
+		}
+		jumpNotTruthyPos = c.emit(operation.JumpNotTruthy, 9999)
+	}
+
+	// See compileWhile: the body's (and the post statement's) Pop must
+	// run every iteration, not just once.
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+
+	postPos := len(c.currentInstructions())
+	if node.Post != nil {
+		if err := c.Compile(node.Post); err != nil {
+			return err
+		}
+	}
+
+	c.emit(operation.Jump, conditionPos)
+
+	afterLoopPos := len(c.currentInstructions())
+	if jumpNotTruthyPos != -1 {
+		c.changeOperand(jumpNotTruthyPos, afterLoopPos)
+	}
+
+	c.leaveLoop(postPos, afterLoopPos)
+	c.emit(operation.Null)
+
+	return nil
+}
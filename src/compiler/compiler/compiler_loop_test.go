@@ -0,0 +1,128 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/ape-lang/ape/src/ast"
+	"github.com/ape-lang/ape/src/compiler/operation"
+)
+
+// countOpcode walks ins decoding one instruction at a time (rather than
+// scanning raw bytes, which could false-positive on an operand byte that
+// happens to match want's numeric value) and counts how many times want
+// occurs.
+func countOpcode(t *testing.T, ins operation.Instruction, want operation.Opcode) int {
+	t.Helper()
+
+	count := 0
+	for i := 0; i < len(ins); {
+		def, err := operation.Lookup(ins[i])
+		if err != nil {
+			t.Fatalf("undefined opcode byte %d at offset %d", ins[i], i)
+		}
+		if operation.Opcode(ins[i]) == want {
+			count++
+		}
+		width := 1
+		for _, size := range def.OperandSizes {
+			width += size
+		}
+		i += width
+	}
+	return count
+}
+
+func intStatement(value int64) *ast.ExpressionStatement {
+	return &ast.ExpressionStatement{Expression: &ast.IntegerLiteral{Value: value}}
+}
+
+// A loop body is compiled exactly once but, unlike an if-branch, runs
+// against that one compiled copy on every iteration. Its trailing
+// expression statement must still emit (and execute) a Pop each time;
+// if the compiler instead prevents that Pop from ever being emitted (the
+// `if`-branch idiom), every iteration leaks one slot onto the operand
+// stack.
+func TestCompileWhile_BodyPopSurvives(t *testing.T) {
+	c := New()
+
+	node := &ast.WhileExpression{
+		Condition: &ast.Boolean{Value: true},
+		Body:      &ast.BlockStatement{Statements: []ast.Statement{intStatement(5)}},
+	}
+
+	if err := c.Compile(node); err != nil {
+		t.Fatalf("Compile returned error: %v", err)
+	}
+
+	if got := countOpcode(t, c.currentInstructions(), operation.Pop); got != 1 {
+		t.Errorf("expected the loop body's Pop to be emitted exactly once, got %d", got)
+	}
+}
+
+func TestCompileDoWhile_BodyPopSurvives(t *testing.T) {
+	c := New()
+
+	node := &ast.DoWhileExpression{
+		Body:      &ast.BlockStatement{Statements: []ast.Statement{intStatement(5)}},
+		Condition: &ast.Boolean{Value: false},
+	}
+
+	if err := c.Compile(node); err != nil {
+		t.Fatalf("Compile returned error: %v", err)
+	}
+
+	if got := countOpcode(t, c.currentInstructions(), operation.Pop); got != 1 {
+		t.Errorf("expected the loop body's Pop to be emitted exactly once, got %d", got)
+	}
+}
+
+// For a `for` loop, both the body and the post statement run once per
+// iteration against their single compiled copy, so both of their Pops
+// must survive.
+func TestCompileFor_BodyAndPostPopsSurvive(t *testing.T) {
+	c := New()
+
+	node := &ast.ForExpression{
+		Condition: &ast.Boolean{Value: true},
+		Post:      intStatement(1),
+		Body:      &ast.BlockStatement{Statements: []ast.Statement{intStatement(5)}},
+	}
+
+	if err := c.Compile(node); err != nil {
+		t.Fatalf("Compile returned error: %v", err)
+	}
+
+	if got := countOpcode(t, c.currentInstructions(), operation.Pop); got != 2 {
+		t.Errorf("expected the body's and post's Pop to each be emitted once (2 total), got %d", got)
+	}
+}
+
+// A break/continue is only legal against a loop in the same function
+// scope. Without its own, empty loop stack, a function literal compiled
+// while an enclosing loop is still open would otherwise accept a break
+// meant for that outer loop and record its jump position against a byte
+// offset in the *outer* scope's instruction stream instead -- silent
+// bytecode corruption rather than a compile error.
+func TestCompileBreak_RejectsLoopFromEnclosingFunctionScope(t *testing.T) {
+	c := New()
+
+	node := &ast.WhileExpression{
+		Condition: &ast.Boolean{Value: true},
+		Body: &ast.BlockStatement{
+			Statements: []ast.Statement{
+				&ast.LetStatement{
+					Name: &ast.Identifier{Value: "f"},
+					Value: &ast.FunctionLiteral{
+						Body: &ast.BlockStatement{
+							Statements: []ast.Statement{&ast.BreakStatement{}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if err := c.Compile(node); err == nil {
+		t.Fatal("expected a break inside a nested function literal to be rejected as outside of a loop")
+	}
+}
@@ -0,0 +1,95 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/ape-lang/ape/src/compiler/module"
+	"github.com/ape-lang/ape/src/compiler/operation"
+	"github.com/ape-lang/ape/src/data"
+)
+
+// RegisterModule makes an in-memory module available to every subsequent
+// `import(name)` compiled by c, without touching disk.
+func (c *Compiler) RegisterModule(name string, mod *module.Module) {
+	c.modules.RegisterModule(name, mod)
+}
+
+// SetModuleSearchPath configures the directories, and file extension,
+// used to resolve an import that isn't registered in-memory.
+func (c *Compiler) SetModuleSearchPath(dirs []string, extension string) {
+	c.modules.SearchPath = dirs
+	if extension != "" {
+		c.modules.Extension = extension
+	}
+}
+
+// Dependencies returns the names of every module imported anywhere in
+// the program compiled so far.
+func (c *Compiler) Dependencies() []string {
+	names := make([]string, 0, len(c.moduleReferenced))
+	for name := range c.moduleReferenced {
+		names = append(names, name)
+	}
+	return names
+}
+
+// inProgress marks a module in compiledModules as still being compiled,
+// so a cycle (m1 importing m2 importing m1) is caught as a compile
+// error instead of recursing through compileModule -> Compile ->
+// compileModule without end.
+const inProgress = -1
+
+// compileModule resolves and compiles the named module's exports exactly
+// once, returning the constant pool index of the resulting function. A
+// module's body is compiled the same way a function literal's body is,
+// so its top-level `let`s become locals of the synthesized function
+// rather than globals that could collide with the importer's own.
+// Every later import of the same module reuses the cached index
+// instead of re-parsing the source or re-emitting its body.
+func (c *Compiler) compileModule(name string) (int, error) {
+	c.moduleReferenced[name] = true
+
+	if idx, ok := c.compiledModules[name]; ok {
+		if idx == inProgress {
+			return 0, fmt.Errorf("module %q: import cycle", name)
+		}
+		return idx, nil
+	}
+
+	mod, err := c.modules.Resolve(name)
+	if err != nil {
+		return 0, err
+	}
+
+	c.compiledModules[name] = inProgress
+
+	c.enterScope()
+
+	if err := c.Compile(mod.AST); err != nil {
+		c.leaveScope()
+		delete(c.compiledModules, name)
+		return 0, fmt.Errorf("module %q: %w", name, err)
+	}
+
+	if c.isEmitted(operation.Pop) {
+		c.changeEmittedTo(operation.ReturnValue)
+	}
+	if !c.isEmitted(operation.ReturnValue) {
+		c.emit(operation.Return)
+	}
+
+	localCount := c.symbols.DefinitionCount
+	modSourceMap := c.currentSourceMap()
+	instructions := c.leaveScope()
+
+	fn := &data.CompiledFunction{
+		Instructions: instructions,
+		LocalCount:   localCount,
+	}
+
+	idx := c.addConstant(fn)
+	c.constantSourceMaps[idx] = modSourceMap
+	c.compiledModules[name] = idx
+
+	return idx, nil
+}
@@ -0,0 +1,34 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/ape-lang/ape/src/ast"
+	"github.com/ape-lang/ape/src/compiler/module"
+)
+
+func importStatement(name string) ast.Statement {
+	return &ast.ExpressionStatement{Expression: &ast.ImportExpression{ModuleName: name}}
+}
+
+// Two modules that import each other used to recurse through
+// compileModule -> Compile -> compileModule without end, since a module
+// is only marked compiled after its body finishes compiling. It must
+// instead come back as a compile error.
+func TestCompileModule_CycleIsAnError(t *testing.T) {
+	c := New()
+
+	c.RegisterModule("a", &module.Module{
+		Name: "a",
+		AST:  &ast.Program{Statements: []ast.Statement{importStatement("b")}},
+	})
+	c.RegisterModule("b", &module.Module{
+		Name: "b",
+		AST:  &ast.Program{Statements: []ast.Statement{importStatement("a")}},
+	})
+
+	err := c.Compile(importStatement("a"))
+	if err == nil {
+		t.Fatal("expected an import cycle to be reported as a compile error")
+	}
+}
@@ -0,0 +1,69 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/ape-lang/ape/src/ast"
+	"github.com/ape-lang/ape/src/data"
+)
+
+// A function body is compiled in its own scope whose instructions start
+// over at offset 0, just like the main program's do. Recording its
+// statements into one shared source map would mix unrelated offsets
+// together; this test compiles a program with a top-level function and
+// asserts the root and function source maps stay separate.
+func TestCompile_FunctionBodyGetsItsOwnSourceMap(t *testing.T) {
+	program := &ast.Program{
+		Statements: []ast.Statement{
+			&ast.LetStatement{
+				Name:  &ast.Identifier{Value: "x"},
+				Value: &ast.IntegerLiteral{Value: 5},
+			},
+			&ast.LetStatement{
+				Name: &ast.Identifier{Value: "f"},
+				Value: &ast.FunctionLiteral{
+					Body: &ast.BlockStatement{
+						Statements: []ast.Statement{
+							intStatement(1),
+							intStatement(2),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	c := New()
+	if err := c.Compile(program); err != nil {
+		t.Fatalf("Compile returned error: %v", err)
+	}
+
+	bc := c.Bytecode()
+
+	if got := len(bc.SourceMap.Entries); got != 2 {
+		t.Fatalf("expected the root map to hold exactly the 2 top-level let statements, got %d entries", got)
+	}
+
+	var fn *data.CompiledFunction
+	var fnIndex int
+	for i, constant := range bc.Constants {
+		if f, ok := constant.(*data.CompiledFunction); ok {
+			fn, fnIndex = f, i
+			break
+		}
+	}
+	if fn == nil {
+		t.Fatal("expected a compiled function constant")
+	}
+
+	fnSourceMap := bc.ConstantSourceMaps[fnIndex]
+	if fnSourceMap == nil {
+		t.Fatal("expected the function constant to have its own source map")
+	}
+	if got := len(fnSourceMap.Entries); got != 2 {
+		t.Fatalf("expected the function's map to hold its own 2 statements, got %d entries", got)
+	}
+	if got := fnSourceMap.Entries[0].InstructionPos; got != 0 {
+		t.Errorf("expected the function body's first statement to start its own map at offset 0, got %d", got)
+	}
+}
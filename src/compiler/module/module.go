@@ -0,0 +1,99 @@
+// Package module resolves the name in an ape `import(...)` expression to
+// parsed ape source, either registered in-memory by the host program or
+// loaded from disk, so the compiler can compile a module's exports
+// exactly once and share the result across every import site that asks
+// for it.
+package module
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ape-lang/ape/src/ast"
+	"github.com/ape-lang/ape/src/lexer"
+	"github.com/ape-lang/ape/src/parser"
+)
+
+// Module is a single importable unit of ape source: either parsed from a
+// file on disk or registered directly by the host program.
+type Module struct {
+	Name string
+	AST  *ast.Program
+}
+
+// Registry resolves module names to parsed Modules. In-memory
+// registrations always take priority over disk lookups; disk lookups are
+// cached by resolved file path so importing the same module from two
+// different names/call-sites never re-reads or re-parses the file.
+type Registry struct {
+	registered map[string]*Module
+	parsed     map[string]*Module // keyed by resolved file path (SearchPath dir joined with name and Extension)
+
+	// SearchPath is checked, in order, when a module isn't registered.
+	SearchPath []string
+	// Extension is appended to a module name to form a file name, e.g. ".ape".
+	Extension string
+}
+
+// NewRegistry creates an empty Registry that only resolves modules
+// registered with RegisterModule until a SearchPath is configured.
+func NewRegistry() *Registry {
+	return &Registry{
+		registered: map[string]*Module{},
+		parsed:     map[string]*Module{},
+		Extension:  ".ape",
+	}
+}
+
+// RegisterModule makes an in-memory module available under name, without
+// touching disk. This is how a host program exposes a library of
+// functions to every ape program it compiles.
+func (r *Registry) RegisterModule(name string, mod *Module) {
+	r.registered[name] = mod
+}
+
+// Resolve returns the parsed Module for name, checking in-memory
+// registrations first, then locating the file on SearchPath and reusing
+// the Module already parsed from that path, if any.
+func (r *Registry) Resolve(name string) (*Module, error) {
+	if mod, ok := r.registered[name]; ok {
+		return mod, nil
+	}
+
+	path, err := r.find(name)
+	if err != nil {
+		return nil, err
+	}
+	if mod, ok := r.parsed[path]; ok {
+		return mod, nil
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("module %q: %w", name, err)
+	}
+
+	p := parser.New(lexer.New(string(source)))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		return nil, fmt.Errorf("module %q: %s", name, strings.Join(errs, "; "))
+	}
+
+	mod := &Module{Name: name, AST: program}
+	r.parsed[path] = mod
+
+	return mod, nil
+}
+
+// find locates the file backing name by walking SearchPath in order.
+func (r *Registry) find(name string) (string, error) {
+	for _, dir := range r.SearchPath {
+		candidate := filepath.Join(dir, name+r.Extension)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("module %q not found in search path", name)
+}
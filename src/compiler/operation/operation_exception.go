@@ -0,0 +1,26 @@
+package operation
+
+// NoHandler marks a Try instruction's CatchPos/FinallyPos operand as
+// absent, i.e. the try has no catch clause, or no finally clause,
+// respectively.
+const NoHandler = 0xFFFF
+
+// Opcodes backing try/catch/finally. Grouped in their own block, and
+// registered into the shared operations map in init, rather than folded
+// into the main Opcode list so the exception-handling surface stays easy
+// to find and to drop as a unit if it ever needs to change shape.
+const (
+	Try Opcode = iota + 100
+	PopTry
+	Raise
+	EndFinally
+	IndexChecked
+)
+
+func init() {
+	operations[Try] = &Operation{"Try", []int{2, 2}}
+	operations[PopTry] = &Operation{"PopTry", []int{}}
+	operations[Raise] = &Operation{"Raise", []int{}}
+	operations[EndFinally] = &Operation{"EndFinally", []int{}}
+	operations[IndexChecked] = &Operation{"IndexChecked", []int{}}
+}
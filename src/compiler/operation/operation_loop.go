@@ -0,0 +1,17 @@
+package operation
+
+// LoopExit backs `break` and `continue`. It looks like a plain Jump in
+// that it carries a single target operand, but it means something
+// different to the VM: before actually jumping, it first runs any
+// try/finally opened since the loop was entered, in the current frame,
+// exactly like a `return` does. Using a dedicated opcode rather than
+// reusing Jump keeps that unwind out of the loop's own internal jumps
+// (the condition check, the back-edge to the top), which must not
+// trigger it.
+const (
+	LoopExit Opcode = iota + 200
+)
+
+func init() {
+	operations[LoopExit] = &Operation{"LoopExit", []int{2}}
+}
@@ -0,0 +1,75 @@
+// Package sourcemap links generated bytecode instruction offsets, within
+// one compiled function's instruction stream, back to the ape source
+// that produced them. A Lookup is used to annotate a VM runtime error,
+// or to disassemble a program, with the original source snippet.
+package sourcemap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Entry links one generated instruction offset back to the source
+// snippet whose compilation started there.
+type Entry struct {
+	InstructionPos int
+	Source         string
+}
+
+// Map is an ordered source map for a single function's instructions.
+// Entries are kept sorted by InstructionPos whatever order they are
+// recorded in (the compiler records a nested block's statements before
+// the statement enclosing them). Entries is exported so a Map round-trips
+// through encoding/gob.
+type Map struct {
+	Entries []Entry
+}
+
+// New creates an empty Map.
+func New() *Map {
+	return &Map{}
+}
+
+// Record notes that the instructions starting at instructionPos were
+// produced by compiling source. A repeat record for the same offset (a
+// statement that emitted nothing, e.g. a no-op branch) is ignored so the
+// first, most specific, entry wins.
+func (m *Map) Record(instructionPos int, source string) {
+	i := sort.Search(len(m.Entries), func(i int) bool {
+		return m.Entries[i].InstructionPos >= instructionPos
+	})
+	if i < len(m.Entries) && m.Entries[i].InstructionPos == instructionPos {
+		return
+	}
+	m.Entries = append(m.Entries, Entry{})
+	copy(m.Entries[i+1:], m.Entries[i:])
+	m.Entries[i] = Entry{InstructionPos: instructionPos, Source: source}
+}
+
+// Lookup returns the source snippet responsible for the instruction at
+// or immediately before pos.
+func (m *Map) Lookup(pos int) (string, bool) {
+	if m == nil || len(m.Entries) == 0 {
+		return "", false
+	}
+
+	best := m.Entries[0]
+	for _, e := range m.Entries {
+		if e.InstructionPos > pos {
+			break
+		}
+		best = e
+	}
+	return best.Source, true
+}
+
+// Dump renders the whole map as "offset: source" lines, for editor
+// tooling that wants to resolve a generated-bytecode offset on its own.
+func (m *Map) Dump() string {
+	var b strings.Builder
+	for _, e := range m.Entries {
+		fmt.Fprintf(&b, "%d: %s\n", e.InstructionPos, e.Source)
+	}
+	return b.String()
+}
@@ -0,0 +1,218 @@
+// Package pool runs one compiled ape program across several VM instances
+// concurrently, each its own goroutine working a disjoint slice of an
+// iteration space, then combines their results. It's the concurrent
+// counterpart to running a single compiler.Bytecode on a single vm.VM:
+// every worker shares the same constants and instructions, but gets its
+// own stack, frames, and globals.
+package pool
+
+import (
+	"sync"
+
+	"github.com/ape-lang/ape/src/compiler/compiler"
+	"github.com/ape-lang/ape/src/compiler/vm"
+	"github.com/ape-lang/ape/src/data"
+)
+
+// Range is a half-open sub-range [Start, End) of an iteration space.
+type Range struct {
+	Start, End int
+}
+
+// Strategy decides how a Job's iteration space is divided across workers.
+type Strategy interface {
+	Partition(total, workers int) []Range
+}
+
+// Even splits the range into workers contiguous chunks, sized as equally
+// as possible.
+type Even struct{}
+
+// Partition implements Strategy.
+func (Even) Partition(total, workers int) []Range {
+	if workers <= 0 || total <= 0 {
+		return nil
+	}
+	if workers > total {
+		workers = total
+	}
+
+	base, extra := total/workers, total%workers
+	ranges := make([]Range, 0, workers)
+	start := 0
+	for w := 0; w < workers; w++ {
+		size := base
+		if w < extra {
+			size++
+		}
+		ranges = append(ranges, Range{Start: start, End: start + size})
+		start += size
+	}
+	return ranges
+}
+
+// Weighted splits the range proportionally to the value its function
+// returns for each worker index.
+type Weighted func(worker int) float64
+
+// Partition implements Strategy.
+func (f Weighted) Partition(total, workers int) []Range {
+	if workers <= 0 || total <= 0 {
+		return nil
+	}
+
+	weights := make([]float64, workers)
+	sum := 0.0
+	for w := range weights {
+		weights[w] = f(w)
+		sum += weights[w]
+	}
+
+	ranges := make([]Range, 0, workers)
+	start := 0
+	for w := 0; w < workers; w++ {
+		size := total - start // last worker takes the remainder, so rounding can't drop elements
+		if w < workers-1 && sum > 0 {
+			size = int(float64(total) * weights[w] / sum)
+		}
+		ranges = append(ranges, Range{Start: start, End: start + size})
+		start += size
+	}
+	return ranges
+}
+
+// Custom lets the caller compute a worker's sub-range directly. Returning
+// ok=false gives that worker nothing to do.
+type Custom func(worker int, total Range) (r Range, ok bool)
+
+// Partition implements Strategy.
+func (f Custom) Partition(total, workers int) []Range {
+	ranges := make([]Range, 0, workers)
+	for w := 0; w < workers; w++ {
+		if r, ok := f(w, Range{0, total}); ok {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// ArgMode decides how one argument is divided across workers.
+type ArgMode int
+
+const (
+	// Split hands each worker its own slice of the argument array.
+	Split ArgMode = iota
+	// Broadcast hands every worker the whole, unsliced value.
+	Broadcast
+)
+
+// Arg is one value threaded into every worker's globals before it runs,
+// at GlobalIndex (matching the global slot the compiled program reads it
+// from).
+type Arg struct {
+	GlobalIndex int
+	Value       data.Data // must be *data.Array when Mode is Split
+	Mode        ArgMode
+}
+
+// Job describes one parallel run: the program every worker executes, the
+// size of the iteration space, the arguments to divide or broadcast
+// across it, and how many workers to split it across.
+type Job struct {
+	Bytecode *compiler.Bytecode
+	Total    int
+	Args     []Arg
+	Strategy Strategy
+	Workers  int
+}
+
+// Run partitions job.Total across job.Workers using job.Strategy,
+// compiles nothing new (every worker shares job.Bytecode, since there's
+// only one backend to target), and runs each worker's own VM instance
+// concurrently, returning one result per partitioned range, in range
+// order (fewer than job.Workers when the Strategy yields fewer). Callers
+// combine those results with Concat or Reduce depending on what the
+// program computes.
+func Run(job Job) ([]data.Data, error) {
+	workers := job.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	ranges := job.Strategy.Partition(job.Total, workers)
+	results := make([]data.Data, len(ranges))
+	errs := make([]error, len(ranges))
+
+	var wg sync.WaitGroup
+	for i, r := range ranges {
+		wg.Add(1)
+		go func(i int, r Range) {
+			defer wg.Done()
+
+			globals := make([]data.Data, vm.GlobalsLimit)
+			for _, arg := range job.Args {
+				globals[arg.GlobalIndex] = sliceArg(arg, r)
+			}
+
+			worker := vm.NewWithGlobals(job.Bytecode, globals)
+			if err := worker.Run(); err != nil {
+				errs[i] = err
+				return
+			}
+			results[i] = worker.Result()
+		}(i, r)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func sliceArg(arg Arg, r Range) data.Data {
+	if arg.Mode == Broadcast {
+		return arg.Value
+	}
+
+	array, ok := arg.Value.(*data.Array)
+	if !ok {
+		return arg.Value
+	}
+
+	start, end := r.Start, r.End
+	if end > len(array.Elements) {
+		end = len(array.Elements)
+	}
+	if start > end {
+		start = end
+	}
+	return &data.Array{Elements: array.Elements[start:end]}
+}
+
+// Concat concatenates every worker's result, which must each be a
+// *data.Array, back into one array in range order.
+func Concat(results []data.Data) *data.Array {
+	elements := []data.Data{}
+	for _, r := range results {
+		if array, ok := r.(*data.Array); ok {
+			elements = append(elements, array.Elements...)
+		}
+	}
+	return &data.Array{Elements: elements}
+}
+
+// Reduce folds every worker's result together with fn, left to right.
+func Reduce(results []data.Data, fn func(a, b data.Data) data.Data) data.Data {
+	if len(results) == 0 {
+		return nil
+	}
+
+	acc := results[0]
+	for _, r := range results[1:] {
+		acc = fn(acc, r)
+	}
+	return acc
+}
@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"testing"
+
+	"github.com/ape-lang/ape/src/compiler/compiler"
+	"github.com/ape-lang/ape/src/compiler/operation"
+	"github.com/ape-lang/ape/src/data"
+)
+
+// program just reads back global 0 and leaves it as the worker's result,
+// so Concat can be checked against the original, unsplit array.
+func identityProgram() *compiler.Bytecode {
+	var ins operation.Instruction
+	ins = append(ins, operation.NewInstruction(operation.GetGlobal, 0)...)
+	ins = append(ins, operation.NewInstruction(operation.Pop)...)
+	return &compiler.Bytecode{Instructions: ins}
+}
+
+func TestRun_SplitArgConcatenatesBackInOrder(t *testing.T) {
+	elements := []data.Data{
+		&data.Integer{Value: 1}, &data.Integer{Value: 2}, &data.Integer{Value: 3},
+		&data.Integer{Value: 4}, &data.Integer{Value: 5}, &data.Integer{Value: 6},
+	}
+
+	job := Job{
+		Bytecode: identityProgram(),
+		Total:    len(elements),
+		Args: []Arg{
+			{GlobalIndex: 0, Value: &data.Array{Elements: elements}, Mode: Split},
+		},
+		Strategy: Even{},
+		Workers:  3,
+	}
+
+	results, err := Run(job)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	got := Concat(results)
+	if len(got.Elements) != len(elements) {
+		t.Fatalf("expected %d elements back, got %d", len(elements), len(got.Elements))
+	}
+	for i, el := range got.Elements {
+		want := elements[i].(*data.Integer).Value
+		have, ok := el.(*data.Integer)
+		if !ok || have.Value != want {
+			t.Errorf("element %d = %#v, want Integer(%d)", i, el, want)
+		}
+	}
+}
+
+func TestRun_BroadcastArgSendsWholeValueToEveryWorker(t *testing.T) {
+	shared := &data.Array{Elements: []data.Data{&data.Integer{Value: 42}}}
+
+	job := Job{
+		Bytecode: identityProgram(),
+		Total:    4,
+		Args: []Arg{
+			{GlobalIndex: 0, Value: shared, Mode: Broadcast},
+		},
+		Strategy: Even{},
+		Workers:  2,
+	}
+
+	results, err := Run(job)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	for i, r := range results {
+		array, ok := r.(*data.Array)
+		if !ok || len(array.Elements) != 1 {
+			t.Fatalf("worker %d result = %#v, want the whole broadcast array", i, r)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/ape-lang/ape/src/compiler/compiler"
 	"github.com/ape-lang/ape/src/compiler/operation"
+	"github.com/ape-lang/ape/src/compiler/sourcemap"
 	"github.com/ape-lang/ape/src/data"
 )
 
@@ -25,6 +26,30 @@ type VM struct {
 	globals   []data.Data
 	stack     *Stack
 	frames    *Frames
+
+	frameDepth   int          // number of call frames pushed since New, tracked alongside frames so a raise can unwind to the frame depth a try was set up at
+	handlers     []tryHandler // active try/catch/finally blocks, innermost last
+	pendingRaise data.Data    // set while a finally block runs because of an in-flight raise with no matching catch
+
+	// pendingReturn and pendingLoopExitTarget are pendingRaise's
+	// counterparts for the other two ways of leaving a try body: a
+	// `return` and a `break`/`continue` that crosses it. Each is set
+	// right before jumping into a finally block so that executeEndFinally
+	// knows how to resume once that finally finishes, and is nil/unset
+	// the rest of the time. At most one of pendingRaise, pendingReturn and
+	// pendingLoopExitTarget is live at a time.
+	pendingReturn         data.Data
+	pendingLoopExitTarget *int
+
+	// sourceMaps parallels frames: sourceMaps[i] links the instructions
+	// of the function running in frame i back to the ape source that
+	// produced them (nil if that function has none). Every function has
+	// its own instruction stream starting at offset 0, so each frame
+	// needs its own map rather than sharing the main function's.
+	sourceMaps []*sourcemap.Map
+	// sourceMapByFn looks up the source map for a *data.CompiledFunction
+	// being called, built once in New from Bytecode.ConstantSourceMaps.
+	sourceMapByFn map[*data.CompiledFunction]*sourcemap.Map
 }
 
 // New creates a new VM from the given Bytecode
@@ -37,11 +62,31 @@ func New(bytecode *compiler.Bytecode) *VM {
 	frames.push(mainFrame)
 
 	return &VM{
-		constants: bytecode.Constants,
-		globals:   make([]data.Data, GlobalsLimit),
-		stack:     NewStack(StackLimit),
-		frames:    frames,
+		constants:     bytecode.Constants,
+		globals:       make([]data.Data, GlobalsLimit),
+		stack:         NewStack(StackLimit),
+		frames:        frames,
+		sourceMaps:    []*sourcemap.Map{bytecode.SourceMap},
+		sourceMapByFn: functionSourceMaps(bytecode),
+	}
+}
+
+// functionSourceMaps builds the lookup New needs to find a called
+// function's own source map, by zipping bytecode.Constants with
+// bytecode.ConstantSourceMaps by index. Built fresh per VM rather than
+// carried on data.CompiledFunction itself, so a program reloaded from
+// the bytecode cache works the same as one compiled just now.
+func functionSourceMaps(bytecode *compiler.Bytecode) map[*data.CompiledFunction]*sourcemap.Map {
+	byFn := make(map[*data.CompiledFunction]*sourcemap.Map)
+	for i, constant := range bytecode.Constants {
+		if i >= len(bytecode.ConstantSourceMaps) || bytecode.ConstantSourceMaps[i] == nil {
+			continue
+		}
+		if fn, ok := constant.(*data.CompiledFunction); ok {
+			byFn[fn] = bytecode.ConstantSourceMaps[i]
+		}
 	}
+	return byFn
 }
 
 // NewWithGlobals creates a new VM instance with closure over a globals array (for persistance)
@@ -56,8 +101,35 @@ func (vm *VM) Result() data.Data {
 	return vm.stack.popped()
 }
 
-// Run executes every instruction given to the VM on creation
+// Run executes every instruction given to the VM on creation. On
+// failure, the returned error is annotated with the ape source snippet
+// the failing instruction was generated from, when a source map is
+// available, so a raised or runtime error points back at the user's
+// source rather than just a bytecode offset.
 func (vm *VM) Run() error {
+	if err := vm.run(); err != nil {
+		return vm.annotateError(err)
+	}
+	return nil
+}
+
+// annotateError enriches err with the source snippet responsible for
+// whatever instruction the VM was executing when it failed, using the
+// source map of whichever function was running (not necessarily the
+// main one).
+func (vm *VM) annotateError(err error) error {
+	sourceMap := vm.sourceMaps[len(vm.sourceMaps)-1]
+	if sourceMap == nil {
+		return err
+	}
+	if src, ok := sourceMap.Lookup(vm.frames.current().pointer); ok {
+		return fmt.Errorf("%w (at: %s)", err, src)
+	}
+	return err
+}
+
+// run executes every instruction given to the VM on creation.
+func (vm *VM) run() error {
 	var pointer int
 	var instructions operation.Instruction
 	var op operation.Opcode
@@ -203,21 +275,52 @@ func (vm *VM) Run() error {
 			}
 			frame := NewFrame(fn, vm.stack.pointer)
 			vm.frames.push(frame)
+			vm.frameDepth++
+			vm.sourceMaps = append(vm.sourceMaps, vm.sourceMapByFn[fn])
 			vm.stack.pointer = frame.framePointer + fn.LocalCount
 
 		case operation.ReturnValue:
 			value := vm.stack.pop()
-			frame := vm.frames.pop()
-			vm.stack.pointer = frame.framePointer - 1
-			err := vm.stack.push(value)
-			if err != nil {
+			if err := vm.unwindReturn(value); err != nil {
 				return err
 			}
 
 		case operation.Return:
-			frame := vm.frames.pop()
-			vm.stack.pointer = frame.framePointer - 1
-			err := vm.stack.push(NULL)
+			if err := vm.unwindReturn(NULL); err != nil {
+				return err
+			}
+
+		case operation.LoopExit:
+			target := int(operation.ReadUint16(instructions[pointer+1:]))
+			vm.frames.current().pointer += 2
+			vm.unwindLoopExit(target)
+
+		case operation.Try:
+			catchPos := int(operation.ReadUint16(instructions[pointer+1:]))
+			finallyPos := int(operation.ReadUint16(instructions[pointer+3:]))
+			vm.frames.current().pointer += 4
+			vm.executeSetupTry(catchPos, finallyPos)
+
+		case operation.PopTry:
+			vm.executePopTry()
+
+		case operation.Raise:
+			raised := vm.stack.pop()
+			err := vm.raise(raised)
+			if err != nil {
+				return err
+			}
+
+		case operation.EndFinally:
+			err := vm.executeEndFinally()
+			if err != nil {
+				return err
+			}
+
+		case operation.IndexChecked:
+			index := vm.stack.pop()
+			left := vm.stack.pop()
+			err := vm.executeIndexCheckedOp(left, index)
 			if err != nil {
 				return err
 			}
@@ -226,6 +329,28 @@ func (vm *VM) Run() error {
 	return nil
 }
 
+// popFrame pops the current call frame and discards any handler that try
+// setup inside it (or installed by anything further down the call chain
+// it invoked). Without this, a handler from a frame that has already
+// returned would still be sitting on vm.handlers: a later, unrelated
+// raise further up the call stack could match it and force the stack
+// pointer and instruction pointer to offsets that only meant something
+// inside the callee's own, now-gone, instruction stream. unwindReturn
+// calls this only once every handler belonging to the popped frame has
+// already had its finally run (or been discarded, if it had none), so in
+// practice the loop below rarely finds anything left to trim.
+func (vm *VM) popFrame() *Frame {
+	frame := vm.frames.pop()
+	vm.frameDepth--
+	vm.sourceMaps = vm.sourceMaps[:len(vm.sourceMaps)-1]
+
+	for len(vm.handlers) > 0 && vm.handlers[len(vm.handlers)-1].frameDepth > vm.frameDepth {
+		vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	}
+
+	return frame
+}
+
 func isTruthy(d data.Data) bool {
 	switch d := d.(type) {
 	case *data.Boolean:
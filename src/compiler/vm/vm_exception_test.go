@@ -0,0 +1,199 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/ape-lang/ape/src/compiler/compiler"
+	"github.com/ape-lang/ape/src/compiler/operation"
+	"github.com/ape-lang/ape/src/data"
+)
+
+// A return executed from inside a try body pops that frame without going
+// through raise(), so the handler it installed must be discarded right
+// there. Otherwise a later, unrelated raise further up the call stack
+// could match a handler whose frameDepth/stackPointer point at a frame
+// that no longer exists.
+func TestReturnFromTry_DoesNotLeakStaleHandler(t *testing.T) {
+	fnBody := append(operation.Instruction{}, operation.NewInstruction(operation.Try, 99, operation.NoHandler)...)
+	fnBody = append(fnBody, operation.NewInstruction(operation.Return)...)
+	fn := &data.CompiledFunction{Instructions: fnBody}
+
+	raiseValue := &data.Error{Message: "boom"}
+
+	var main operation.Instruction
+	main = append(main, operation.NewInstruction(operation.Constant, 0)...) // push fn
+	main = append(main, operation.NewInstruction(operation.Call)...)
+	main = append(main, operation.NewInstruction(operation.Pop)...) // discard the call's NULL result
+	main = append(main, operation.NewInstruction(operation.Constant, 1)...) // push the value to raise
+	main = append(main, operation.NewInstruction(operation.Raise)...)
+
+	bytecode := &compiler.Bytecode{
+		Instructions: main,
+		Constants:    []data.Data{fn, raiseValue},
+	}
+
+	machine := New(bytecode)
+	err := machine.Run()
+	if err == nil {
+		t.Fatal("expected raising with no live handler to return an error")
+	}
+	if got, want := err.Error(), raiseValue.Message; got != want {
+		t.Errorf("raise matched a stale handler left behind by the returned frame: got %q, want %q", got, want)
+	}
+}
+
+// If a try's catch body itself raises, that try's finally must still run
+// before the new raise propagates further. raise() re-installs a
+// finally-only handler before dispatching into catch to guarantee this;
+// the compiler emits a matching PopTry after the catch body to discard
+// that handler again once catch completes without raising.
+func TestRaiseFromCatch_StillRunsFinally(t *testing.T) {
+	var body operation.Instruction
+
+	setupPos := len(body)
+	body = append(body, operation.NewInstruction(operation.Try, operation.NoHandler, operation.NoHandler)...)
+	body = append(body, operation.NewInstruction(operation.Constant, 0)...) // push try-body error
+	body = append(body, operation.NewInstruction(operation.Raise)...)
+	body = append(body, operation.NewInstruction(operation.PopTry)...) // unreached: normal-completion cleanup
+	skipHandlersPos := len(body)
+	body = append(body, operation.NewInstruction(operation.Jump, operation.NoHandler)...)
+
+	catchPos := len(body)
+	body = append(body, operation.NewInstruction(operation.Pop)...)        // discard the caught value, no catch var
+	body = append(body, operation.NewInstruction(operation.Constant, 1)...) // push catch-body error
+	body = append(body, operation.NewInstruction(operation.Raise)...)
+	body = append(body, operation.NewInstruction(operation.PopTry)...) // discard the synthetic finally-only handler
+
+	finallyPos := len(body)
+	body = append(body, operation.NewInstruction(operation.Constant, 2)...) // sentinel value
+	body = append(body, operation.NewInstruction(operation.SetGlobal, 0)...)
+	body = append(body, operation.NewInstruction(operation.EndFinally)...)
+
+	copy(body[setupPos:], operation.NewInstruction(operation.Try, catchPos, finallyPos))
+	copy(body[skipHandlersPos:], operation.NewInstruction(operation.Jump, finallyPos))
+
+	bytecode := &compiler.Bytecode{
+		Instructions: body,
+		Constants: []data.Data{
+			&data.Error{Message: "try-body-error"},
+			&data.Error{Message: "catch-body-error"},
+			&data.Integer{Value: 42},
+		},
+	}
+
+	machine := New(bytecode)
+	err := machine.Run()
+	if err == nil {
+		t.Fatal("expected the re-raised catch-body error to surface once finally has run")
+	}
+	if got, want := err.Error(), "catch-body-error"; got != want {
+		t.Errorf("got error %q, want %q", got, want)
+	}
+
+	sentinel, ok := machine.globals[0].(*data.Integer)
+	if !ok || sentinel.Value != 42 {
+		t.Fatalf("finally block did not run: globals[0] = %#v", machine.globals[0])
+	}
+}
+
+// A `return` from inside a try body, in the same frame the try was set
+// up in, must still run that try's finally before the function actually
+// returns. ReturnValue used to call popFrame directly, which discarded
+// the handler without ever jumping into finallyPos.
+func TestReturnFromTry_StillRunsFinally(t *testing.T) {
+	var fnBody operation.Instruction
+
+	setupPos := len(fnBody)
+	fnBody = append(fnBody, operation.NewInstruction(operation.Try, operation.NoHandler, operation.NoHandler)...)
+	fnBody = append(fnBody, operation.NewInstruction(operation.Constant, 1)...) // push the return value, 5
+	fnBody = append(fnBody, operation.NewInstruction(operation.ReturnValue)...)
+
+	finallyPos := len(fnBody)
+	fnBody = append(fnBody, operation.NewInstruction(operation.Constant, 2)...) // sentinel value
+	fnBody = append(fnBody, operation.NewInstruction(operation.SetGlobal, 0)...)
+	fnBody = append(fnBody, operation.NewInstruction(operation.EndFinally)...)
+
+	copy(fnBody[setupPos:], operation.NewInstruction(operation.Try, operation.NoHandler, finallyPos))
+
+	fn := &data.CompiledFunction{Instructions: fnBody}
+
+	var main operation.Instruction
+	main = append(main, operation.NewInstruction(operation.Constant, 0)...) // push fn
+	main = append(main, operation.NewInstruction(operation.Call)...)
+	main = append(main, operation.NewInstruction(operation.SetGlobal, 1)...) // store fn's return value
+
+	bytecode := &compiler.Bytecode{
+		Instructions: main,
+		Constants: []data.Data{
+			fn,
+			&data.Integer{Value: 5},
+			&data.Integer{Value: 99},
+		},
+	}
+
+	machine := New(bytecode)
+	if err := machine.Run(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	sentinel, ok := machine.globals[0].(*data.Integer)
+	if !ok || sentinel.Value != 99 {
+		t.Fatalf("finally block did not run: globals[0] = %#v", machine.globals[0])
+	}
+
+	returned, ok := machine.globals[1].(*data.Integer)
+	if !ok || returned.Value != 5 {
+		t.Fatalf("function's return value was lost: globals[1] = %#v", machine.globals[1])
+	}
+}
+
+// A `break`/`continue` that jumps out of a loop nested inside a
+// try/finally, with no function call in between, must run that finally
+// too. The raw Jump break/continue used to compile to skipped straight
+// past the try's handler-cleanup instructions, leaving a stale handler
+// on vm.handlers and the finally body unreached; LoopExit fixes this by
+// consulting vm.handlers the same way a return or raise does.
+func TestLoopExitFromTry_StillRunsFinally(t *testing.T) {
+	var body operation.Instruction
+
+	setupPos := len(body)
+	body = append(body, operation.NewInstruction(operation.Try, operation.NoHandler, operation.NoHandler)...)
+
+	loopExitPos := len(body)
+	body = append(body, operation.NewInstruction(operation.LoopExit, operation.NoHandler)...) // "break"
+
+	finallyPos := len(body)
+	body = append(body, operation.NewInstruction(operation.Constant, 0)...) // sentinel value
+	body = append(body, operation.NewInstruction(operation.SetGlobal, 0)...)
+	body = append(body, operation.NewInstruction(operation.EndFinally)...)
+
+	afterLoopPos := len(body)
+	body = append(body, operation.NewInstruction(operation.Constant, 1)...) // reached-after-loop marker
+	body = append(body, operation.NewInstruction(operation.SetGlobal, 1)...)
+
+	copy(body[setupPos:], operation.NewInstruction(operation.Try, operation.NoHandler, finallyPos))
+	copy(body[loopExitPos:], operation.NewInstruction(operation.LoopExit, afterLoopPos))
+
+	bytecode := &compiler.Bytecode{
+		Instructions: body,
+		Constants: []data.Data{
+			&data.Integer{Value: 42},
+			&data.Integer{Value: 7},
+		},
+	}
+
+	machine := New(bytecode)
+	if err := machine.Run(); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	sentinel, ok := machine.globals[0].(*data.Integer)
+	if !ok || sentinel.Value != 42 {
+		t.Fatalf("finally block did not run: globals[0] = %#v", machine.globals[0])
+	}
+
+	marker, ok := machine.globals[1].(*data.Integer)
+	if !ok || marker.Value != 7 {
+		t.Fatalf("break did not resume at its target after finally: globals[1] = %#v", machine.globals[1])
+	}
+}
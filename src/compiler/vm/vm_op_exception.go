@@ -0,0 +1,132 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/ape-lang/ape/src/compiler/operation"
+	"github.com/ape-lang/ape/src/data"
+)
+
+// tryHandler is a pending try/catch/finally block: where to resume if the
+// protected body (or anything it calls) raises, how far to unwind the
+// operand stack first, and how many call frames were live when the
+// handler was installed, so a raise from deep in a callee unwinds all the
+// way back out to it.
+type tryHandler struct {
+	frameDepth   int
+	stackPointer int
+	catchPos     int
+	finallyPos   int
+}
+
+func (vm *VM) executeSetupTry(catchPos, finallyPos int) {
+	vm.handlers = append(vm.handlers, tryHandler{
+		frameDepth:   vm.frameDepth,
+		stackPointer: vm.stack.pointer,
+		catchPos:     catchPos,
+		finallyPos:   finallyPos,
+	})
+}
+
+func (vm *VM) executePopTry() {
+	if len(vm.handlers) > 0 {
+		vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	}
+}
+
+// raise unwinds the handler stack looking for a try block that is still
+// live. The first matching catch receives the raised value on top of the
+// stack; a finally with no catch of its own re-raises once it has run
+// (see executeEndFinally). With no handler left anywhere, the raise
+// becomes a typed Go error so the host sees it out of Run.
+func (vm *VM) raise(raised data.Data) error {
+	for len(vm.handlers) > 0 {
+		h := vm.handlers[len(vm.handlers)-1]
+		vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+		for vm.frameDepth > h.frameDepth {
+			vm.popFrame()
+		}
+		vm.stack.pointer = h.stackPointer
+
+		if h.catchPos != operation.NoHandler {
+			// If this try also has a finally, it still has to run even if
+			// the catch body itself raises, so re-install a finally-only
+			// handler before entering catch. The compiler emits a matching
+			// PopTry after the catch body to discard it again once catch
+			// completes normally.
+			if h.finallyPos != operation.NoHandler {
+				vm.handlers = append(vm.handlers, tryHandler{
+					frameDepth:   h.frameDepth,
+					stackPointer: h.stackPointer,
+					catchPos:     operation.NoHandler,
+					finallyPos:   h.finallyPos,
+				})
+			}
+			if err := vm.stack.push(raised); err != nil {
+				return err
+			}
+			vm.frames.current().pointer = h.catchPos - 1
+			return nil
+		}
+
+		if h.finallyPos != operation.NoHandler {
+			vm.pendingRaise = raised
+			vm.frames.current().pointer = h.finallyPos - 1
+			return nil
+		}
+	}
+
+	if errVal, ok := raised.(*data.Error); ok {
+		return fmt.Errorf(errVal.Message)
+	}
+	return fmt.Errorf("unhandled raise: %s", raised.Inspect())
+}
+
+// executeEndFinally resumes whichever of a raise, a return or a
+// break/continue sent execution into the finally block that just
+// finished running, rather than the finally being reached by normal
+// fallthrough. At most one of these is ever pending at once.
+func (vm *VM) executeEndFinally() error {
+	switch {
+	case vm.pendingRaise != nil:
+		raised := vm.pendingRaise
+		vm.pendingRaise = nil
+		return vm.raise(raised)
+
+	case vm.pendingReturn != nil:
+		value := vm.pendingReturn
+		vm.pendingReturn = nil
+		return vm.unwindReturn(value)
+
+	case vm.pendingLoopExitTarget != nil:
+		target := *vm.pendingLoopExitTarget
+		vm.pendingLoopExitTarget = nil
+		vm.unwindLoopExit(target)
+	}
+
+	return nil
+}
+
+// executeIndexCheckedOp indexes left by index exactly like Index does,
+// except an out-of-range array access raises an IndexOutOfBounds error
+// through the handler stack instead of returning NULL or crashing.
+func (vm *VM) executeIndexCheckedOp(left, index data.Data) error {
+	array, ok := left.(*data.Array)
+	if !ok {
+		return vm.executeIndexExpr(left, index)
+	}
+
+	i, ok := index.(*data.Integer)
+	if !ok {
+		return fmt.Errorf("index operator not supported: %s", index.Type())
+	}
+
+	if i.Value < 0 || i.Value > int64(len(array.Elements)-1) {
+		return vm.raise(&data.Error{
+			Message: fmt.Sprintf("IndexOutOfBounds: index %d out of range for array of length %d", i.Value, len(array.Elements)),
+		})
+	}
+
+	return vm.stack.push(array.Elements[i.Value])
+}
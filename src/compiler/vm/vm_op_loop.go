@@ -0,0 +1,55 @@
+package vm
+
+import (
+	"github.com/ape-lang/ape/src/compiler/operation"
+	"github.com/ape-lang/ape/src/data"
+)
+
+// unwindReturn leaves the current frame on a `return`. Before it does, it
+// discards any try/catch/finally handler installed in this same frame
+// (return bypasses catch entirely, same as a raise would once a handler
+// has no catch of its own), but for one still holding a finally, it runs
+// that finally first: value is stashed in pendingReturn and execution is
+// redirected into the finally body, to be resumed by executeEndFinally
+// once that finally body completes. Mirrors how raise unwinds past a
+// handler with no catch.
+func (vm *VM) unwindReturn(value data.Data) error {
+	for len(vm.handlers) > 0 && vm.handlers[len(vm.handlers)-1].frameDepth == vm.frameDepth {
+		h := vm.handlers[len(vm.handlers)-1]
+		vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		vm.stack.pointer = h.stackPointer
+
+		if h.finallyPos != operation.NoHandler {
+			vm.pendingReturn = value
+			vm.frames.current().pointer = h.finallyPos - 1
+			return nil
+		}
+	}
+
+	frame := vm.popFrame()
+	vm.stack.pointer = frame.framePointer - 1
+	return vm.stack.push(value)
+}
+
+// unwindLoopExit is break/continue's equivalent of unwindReturn: before
+// jumping to target (the loop's header or the instruction right after it,
+// chosen by the compiler when it patched this LoopExit), it runs any
+// finally belonging to a try opened since the loop was entered, in this
+// same frame. A loop's own internal jumps (the condition check, the
+// back-edge to its top) are plain Jumps and never go through here, so
+// they can't be mistaken for an exit that has to unwind anything.
+func (vm *VM) unwindLoopExit(target int) {
+	for len(vm.handlers) > 0 && vm.handlers[len(vm.handlers)-1].frameDepth == vm.frameDepth {
+		h := vm.handlers[len(vm.handlers)-1]
+		vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		vm.stack.pointer = h.stackPointer
+
+		if h.finallyPos != operation.NoHandler {
+			vm.pendingLoopExitTarget = &target
+			vm.frames.current().pointer = h.finallyPos - 1
+			return
+		}
+	}
+
+	vm.frames.current().pointer = target - 1
+}